@@ -7,13 +7,17 @@ package stockfighter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httputil"
+	"sync"
+	"time"
 
+	"github.com/donovanhide/stockfighter/orderbook"
 	"github.com/gorilla/websocket"
 )
 
@@ -105,43 +109,130 @@ type gameStateResponse struct {
 type Stockfighter struct {
 	apiKey string
 	debug  bool
+	client *http.Client
+
+	symbolInfoMu sync.Mutex
+	symbolInfo   map[string]*SymbolInfo
+}
+
+// NewStockfighterWithOptions creates a new Stockfighter API instance
+// configured with opts. By default every request is retried on 429 and 5xx
+// responses with exponential backoff; use WithRateLimit to additionally cap
+// the rate of outgoing requests.
+func NewStockfighterWithOptions(apiKey string, opts ...Option) *Stockfighter {
+	sf := &Stockfighter{
+		apiKey: apiKey,
+		client: &http.Client{Transport: &retryTransport{next: http.DefaultTransport}},
+	}
+	for _, opt := range opts {
+		opt(sf)
+	}
+	return sf
 }
 
 // Create new Stockfighter API instance.
 // If debug is true, log all HTTP requests and responses.
 func NewStockfighter(apiKey string, debug bool) *Stockfighter {
-	return &Stockfighter{
-		apiKey: apiKey,
-		debug:  debug,
-	}
+	return NewStockfighterWithOptions(apiKey, WithDebug(debug))
 }
 
 // Check the API Is Up. If venue is a non-empty string, then check that venue.
 // Returns nil if ok, otherwise the error indicates the problem.
 func (sf *Stockfighter) Heartbeat(venue string) error {
+	return sf.HeartbeatContext(context.Background(), venue)
+}
+
+// HeartbeatContext is Heartbeat with a caller-supplied context.
+func (sf *Stockfighter) HeartbeatContext(ctx context.Context, venue string) error {
 	var resp response
 	url := apiUrl("heartbeat")
 	if len(venue) > 0 {
 		url = apiUrl("venues/%s/heartbeat", venue)
 	}
-	return sf.do("GET", url, nil, &resp)
+	return sf.do(ctx, "GET", url, nil, &resp)
 }
 
 // Get the stocks available for trading on a venue.
 func (sf *Stockfighter) Stocks(venue string) ([]Symbol, error) {
+	return sf.StocksContext(context.Background(), venue)
+}
+
+// StocksContext is Stocks with a caller-supplied context.
+func (sf *Stockfighter) StocksContext(ctx context.Context, venue string) ([]Symbol, error) {
 	var resp stocksResponse
 	url := apiUrl("venues/%s/stocks", venue)
-	if err := sf.do("GET", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "GET", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return resp.Symbols, nil
 }
 
+// SymbolInfo returns the cached tick size, lot size and quote currency for a
+// symbol, populating the cache from Stocks on first use.
+func (sf *Stockfighter) SymbolInfo(venue, stock string) (*SymbolInfo, error) {
+	return sf.SymbolInfoContext(context.Background(), venue, stock)
+}
+
+// SymbolInfoContext is SymbolInfo with a caller-supplied context. The
+// Stockfighter API has no endpoint for tick/lot constraints, so a symbol
+// confirmed to exist via Stocks defaults to a tick and lot size of 1 (i.e.
+// any whole number is legal) until overridden with SetSymbolInfo.
+func (sf *Stockfighter) SymbolInfoContext(ctx context.Context, venue, stock string) (*SymbolInfo, error) {
+	key := venue + "/" + stock
+
+	sf.symbolInfoMu.Lock()
+	info, ok := sf.symbolInfo[key]
+	sf.symbolInfoMu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	symbols, err := sf.StocksContext(ctx, venue)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, symbol := range symbols {
+		if symbol.Symbol == stock {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown symbol %s on venue %s", stock, venue)
+	}
+
+	info = &SymbolInfo{Venue: venue, Symbol: stock, PriceTick: 1, LotSize: 1}
+	sf.symbolInfoMu.Lock()
+	if sf.symbolInfo == nil {
+		sf.symbolInfo = make(map[string]*SymbolInfo)
+	}
+	sf.symbolInfo[key] = info
+	sf.symbolInfoMu.Unlock()
+	return info, nil
+}
+
+// SetSymbolInfo overrides the cached SymbolInfo for a symbol, for venues
+// that publish their tick and lot sizes out of band.
+func (sf *Stockfighter) SetSymbolInfo(info *SymbolInfo) {
+	sf.symbolInfoMu.Lock()
+	if sf.symbolInfo == nil {
+		sf.symbolInfo = make(map[string]*SymbolInfo)
+	}
+	sf.symbolInfo[info.Venue+"/"+info.Symbol] = info
+	sf.symbolInfoMu.Unlock()
+}
+
 // Get the orderbook for a particular stock.
 func (sf *Stockfighter) OrderBook(venue, stock string) (*OrderBook, error) {
+	return sf.OrderBookContext(context.Background(), venue, stock)
+}
+
+// OrderBookContext is OrderBook with a caller-supplied context.
+func (sf *Stockfighter) OrderBookContext(ctx context.Context, venue, stock string) (*OrderBook, error) {
 	var resp orderBookResponse
 	url := apiUrl("venues/%s/stocks/%s", venue, stock)
-	if err := sf.do("GET", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "GET", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.OrderBook, nil
@@ -149,9 +240,14 @@ func (sf *Stockfighter) OrderBook(venue, stock string) (*OrderBook, error) {
 
 // Get a quick look at the most recent trade information for a stock.
 func (sf *Stockfighter) Quote(venue, stock string) (*Quote, error) {
+	return sf.QuoteContext(context.Background(), venue, stock)
+}
+
+// QuoteContext is Quote with a caller-supplied context.
+func (sf *Stockfighter) QuoteContext(ctx context.Context, venue, stock string) (*Quote, error) {
 	var resp quoteResponse
 	url := apiUrl("venues/%s/stocks/%s/quote", venue, stock)
-	if err := sf.do("GET", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "GET", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.Quote, nil
@@ -159,13 +255,31 @@ func (sf *Stockfighter) Quote(venue, stock string) (*Quote, error) {
 
 // Place an order
 func (sf *Stockfighter) Place(order *Order) (*OrderState, error) {
+	return sf.PlaceContext(context.Background(), order)
+}
+
+// PlaceContext is Place with a caller-supplied context. Order.Price and
+// Order.Quantity are validated against the target symbol's tick and lot
+// size before the request is sent, returning ErrInvalidTickSize or
+// ErrInvalidLotSize instead of spending a round trip on a rejection.
+func (sf *Stockfighter) PlaceContext(ctx context.Context, order *Order) (*OrderState, error) {
+	info, err := sf.SymbolInfoContext(ctx, order.Venue, order.Stock)
+	if err != nil {
+		return nil, err
+	}
+	if info.PriceTick > 0 && order.Price%info.PriceTick != 0 {
+		return nil, ErrInvalidTickSize
+	}
+	if info.LotSize > 0 && order.Quantity%info.LotSize != 0 {
+		return nil, ErrInvalidLotSize
+	}
 	body, err := encodeJson(order)
 	if err != nil {
 		return nil, err
 	}
 	var resp orderResponse
 	url := apiUrl("venues/%s/stocks/%s/orders", order.Venue, order.Stock)
-	if err := sf.do("POST", url, body, &resp); err != nil {
+	if err := sf.do(ctx, "POST", url, body, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.OrderState, nil
@@ -173,9 +287,14 @@ func (sf *Stockfighter) Place(order *Order) (*OrderState, error) {
 
 // Get the status for an existing order.
 func (sf *Stockfighter) Status(venue, stock string, id uint64) (*OrderState, error) {
+	return sf.StatusContext(context.Background(), venue, stock, id)
+}
+
+// StatusContext is Status with a caller-supplied context.
+func (sf *Stockfighter) StatusContext(ctx context.Context, venue, stock string, id uint64) (*OrderState, error) {
 	var resp orderResponse
 	url := apiUrl("venues/%s/stocks/%s/orders/%d", venue, stock, id)
-	if err := sf.do("GET", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "GET", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.OrderState, nil
@@ -183,9 +302,14 @@ func (sf *Stockfighter) Status(venue, stock string, id uint64) (*OrderState, err
 
 // Cancel an existing order.
 func (sf *Stockfighter) Cancel(venue, stock string, id uint64) (*OrderState, error) {
+	return sf.CancelContext(context.Background(), venue, stock, id)
+}
+
+// CancelContext is Cancel with a caller-supplied context.
+func (sf *Stockfighter) CancelContext(ctx context.Context, venue, stock string, id uint64) (*OrderState, error) {
 	var resp orderResponse
 	url := apiUrl("venues/%s/stocks/%s/orders/%d", venue, stock, id)
-	if err := sf.do("DELETE", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "DELETE", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.OrderState, nil
@@ -194,12 +318,17 @@ func (sf *Stockfighter) Cancel(venue, stock string, id uint64) (*OrderState, err
 // Get the statuses for all an account's orders of a stock on a venue.
 // If stock is a non-empty string, only statuses for that stock are returned
 func (sf *Stockfighter) StockStatus(account, venue, stock string) ([]OrderState, error) {
+	return sf.StockStatusContext(context.Background(), account, venue, stock)
+}
+
+// StockStatusContext is StockStatus with a caller-supplied context.
+func (sf *Stockfighter) StockStatusContext(ctx context.Context, account, venue, stock string) ([]OrderState, error) {
 	url := apiUrl("venues/%s/accounts/%s/orders", venue, account)
 	if len(stock) > 0 {
 		url = apiUrl("venues/%s/accounts/%s/stocks/%s/orders", venue, account, stock)
 	}
 	var resp bulkOrderResponse
-	if err := sf.do("GET", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "GET", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return resp.Orders, nil
@@ -208,19 +337,30 @@ func (sf *Stockfighter) StockStatus(account, venue, stock string) ([]OrderState,
 // Subscribe to a stream of quotes for a venue.
 // If stock is a non-empy string, only quotes for that stock are returned.
 func (sf *Stockfighter) Quotes(account, venue, stock string) (chan *Quote, error) {
+	return sf.QuotesContext(context.Background(), account, venue, stock)
+}
+
+// QuotesContext is Quotes with a caller-supplied context. Canceling ctx
+// closes the underlying websocket and ends the pump goroutine.
+func (sf *Stockfighter) QuotesContext(ctx context.Context, account, venue, stock string) (chan *Quote, error) {
 	url := wsUrl("%s/venues/%s/tickertape", account, venue)
 	if len(stock) > 0 {
 		url = wsUrl("%s/venues/%s/tickertape/stocks/%s", account, venue, stock)
 	}
 	c := make(chan *Quote)
-	return c, sf.pump(url, func(conn *websocket.Conn) error {
+	return c, sf.pump(ctx, url, func(conn *websocket.Conn) error {
 		var quote quoteMessage
 		if err := sf.decodeMessage(conn, &quote); err != nil {
 			close(c)
 			return err
 		}
 		if quote.Ok {
-			c <- &quote.Quote
+			select {
+			case c <- &quote.Quote:
+			case <-ctx.Done():
+				close(c)
+				return ctx.Err()
+			}
 		}
 		return nil
 	})
@@ -229,24 +369,183 @@ func (sf *Stockfighter) Quotes(account, venue, stock string) (chan *Quote, error
 // Subscribe to a stream of executions for a venue.
 // If stock is a non-empy string, only executions for that stock are returned.
 func (sf *Stockfighter) Executions(account, venue, stock string) (chan *Execution, error) {
+	return sf.ExecutionsContext(context.Background(), account, venue, stock)
+}
+
+// ExecutionsContext is Executions with a caller-supplied context. Canceling
+// ctx closes the underlying websocket and ends the pump goroutine.
+func (sf *Stockfighter) ExecutionsContext(ctx context.Context, account, venue, stock string) (chan *Execution, error) {
 	url := wsUrl("%s/venues/%s/executions", account, venue)
 	if len(stock) > 0 {
 		url = wsUrl("%s/venues/%s/executions/stocks/%s", account, venue, stock)
 	}
 	c := make(chan *Execution)
-	return c, sf.pump(url, func(conn *websocket.Conn) error {
+	return c, sf.pump(ctx, url, func(conn *websocket.Conn) error {
 		var execution executionMessage
 		if err := sf.decodeMessage(conn, &execution); err != nil {
 			close(c)
 			return err
 		}
 		if execution.Ok {
-			c <- &execution.Execution
+			select {
+			case c <- &execution.Execution:
+			case <-ctx.Done():
+				close(c)
+				return ctx.Err()
+			}
 		}
 		return nil
 	})
 }
 
+// WatchOrderBook returns a live order Book for a stock on a venue, combining
+// the REST OrderBook snapshot with the streaming Quotes and Executions
+// feeds. Executions only reports the watching account's own fills, so
+// Quotes is used to catch the book drifting out of sync whenever another
+// account trades; either feed dropping triggers a resubscribe and a fresh
+// Resync so a reconnect never leaves a permanent gap. Callers that detect a
+// gap some other way (their own sequence tracking, say) can force the same
+// thing by calling Resync on the returned Book.
+func (sf *Stockfighter) WatchOrderBook(account, venue, stock string) (*orderbook.Book, error) {
+	return sf.WatchOrderBookContext(context.Background(), account, venue, stock)
+}
+
+// WatchOrderBookContext is WatchOrderBook with a caller-supplied context.
+// Canceling ctx tears down the underlying Quotes and Executions feeds and
+// ends the bookWatcher goroutine.
+func (sf *Stockfighter) WatchOrderBookContext(ctx context.Context, account, venue, stock string) (*orderbook.Book, error) {
+	w := &bookWatcher{
+		sf:      sf,
+		ctx:     ctx,
+		account: account,
+		venue:   venue,
+		stock:   stock,
+		book:    orderbook.New(),
+	}
+	w.book.SetResyncFunc(w.resync)
+	if err := w.book.Resync(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w.book, nil
+}
+
+type bookWatcher struct {
+	sf                    *Stockfighter
+	ctx                   context.Context
+	account, venue, stock string
+	book                  *orderbook.Book
+}
+
+// resync re-fetches the REST snapshot and applies it to the book, giving
+// subsequent fills a baseline to reconcile against. It is installed on the
+// Book via SetResyncFunc, so both WatchOrderBook and Book.Resync call it.
+func (w *bookWatcher) resync() error {
+	ob, err := w.sf.OrderBookContext(w.ctx, w.venue, w.stock)
+	if err != nil {
+		return err
+	}
+	w.book.ApplySnapshot(toLevels(ob.Bids), toLevels(ob.Asks), ob.TimeStamp)
+	return nil
+}
+
+// fillSide reports whether an Execution should deplete the book's bid side.
+// Execution.Order is the incoming (aggressor) order, identified by
+// IncomingId rather than StandingId: a buy lifts the resting ask, a sell
+// hits the resting bid, so the side depleted is the opposite of
+// Order.Direction.
+func fillSide(incomingDirection string) bool {
+	return incomingDirection == "sell"
+}
+
+func toLevels(orders StandingOrderSlice) []orderbook.Level {
+	levels := make([]orderbook.Level, len(orders))
+	for i, so := range orders {
+		levels[i] = orderbook.Level{Price: so.Price, Quantity: so.Quantity}
+	}
+	return levels
+}
+
+// run subscribes to the quote and execution feeds and keeps the book in
+// sync until one of them drops, at which point it resubscribes both and
+// resyncs before resuming. The Book discards any fill timestamped at or
+// before its latest snapshot, so fills queued during a resync are
+// reconciled rather than double-applied. It exits once w.ctx is canceled,
+// the same as any other *Context feed's pump goroutine.
+func (w *bookWatcher) run() {
+	for w.runOnce() {
+	}
+}
+
+// runOnce subscribes to one cycle of quote and execution feeds under a
+// sub-context of w.ctx, and pumps events until one of them drops. The
+// sub-context is always canceled before runOnce returns, whether that's
+// because a feed dropped cleanly, a subscription or Resync failed, or w.ctx
+// itself was canceled; that tears down any feed already opened this cycle
+// before the next cycle opens fresh ones, rather than leaking one
+// websocket connection per retry for as long as the failure persists.
+// It reports whether run should attempt another cycle.
+func (w *bookWatcher) runOnce() bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+	subCtx, cancel := context.WithCancel(w.ctx)
+	defer cancel()
+
+	quotes, err := w.sf.QuotesContext(subCtx, w.account, w.venue, w.stock)
+	if err != nil {
+		return !w.sleep()
+	}
+	executions, err := w.sf.ExecutionsContext(subCtx, w.account, w.venue, w.stock)
+	if err != nil {
+		return !w.sleep()
+	}
+	if err := w.book.Resync(); err != nil {
+		return !w.sleep()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer cancel()
+		for execution := range executions {
+			w.book.ApplyFill(fillSide(execution.Order.Direction), execution.Price, execution.Filled, execution.FilledAt)
+		}
+	}()
+	for quote := range quotes {
+		w.checkDrift(quote)
+	}
+	cancel()
+	<-done
+	return true
+}
+
+// sleep pauses for a second before the next resubscribe attempt, returning
+// early (and reporting true) if w.ctx is canceled first so run can stop
+// rather than spin after the caller goes away.
+func (w *bookWatcher) sleep() bool {
+	select {
+	case <-time.After(time.Second):
+		return false
+	case <-w.ctx.Done():
+		return true
+	}
+}
+
+// checkDrift forces a Resync if the book's own best bid/ask no longer
+// matches the market-wide top of book reported by a Quote. This is what
+// catches another account's trade moving the market, since Executions only
+// ever reports the watching account's own fills.
+func (w *bookWatcher) checkDrift(quote *Quote) {
+	if bid, _, ok := w.book.BestBid(); ok && quote.Bid != 0 && bid != quote.Bid {
+		w.book.Resync()
+		return
+	}
+	if ask, _, ok := w.book.BestAsk(); ok && quote.Ask != 0 && ask != quote.Ask {
+		w.book.Resync()
+	}
+}
+
 func (sf *Stockfighter) decodeMessage(conn *websocket.Conn, v interface{}) error {
 	_, msg, err := conn.ReadMessage()
 	if err != nil {
@@ -260,9 +559,14 @@ func (sf *Stockfighter) decodeMessage(conn *websocket.Conn, v interface{}) error
 
 // Start a new level.
 func (sf *Stockfighter) Start(level string) (*Game, error) {
+	return sf.StartContext(context.Background(), level)
+}
+
+// StartContext is Start with a caller-supplied context.
+func (sf *Stockfighter) StartContext(ctx context.Context, level string) (*Game, error) {
 	var resp gameResponse
 	url := gmUrl("levels/%s", level)
-	if err := sf.do("POST", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "POST", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.Game, nil
@@ -270,47 +574,71 @@ func (sf *Stockfighter) Start(level string) (*Game, error) {
 
 // Restart a level using the instance id from a previously started Game.
 func (sf *Stockfighter) Restart(id uint64) error {
+	return sf.RestartContext(context.Background(), id)
+}
+
+// RestartContext is Restart with a caller-supplied context.
+func (sf *Stockfighter) RestartContext(ctx context.Context, id uint64) error {
 	var resp response
 	url := gmUrl("instances/%d/restart", id)
-	return sf.do("POST", url, nil, &resp)
+	return sf.do(ctx, "POST", url, nil, &resp)
 }
 
 // Resume a level using the instance id from a previously started Game.
 func (sf *Stockfighter) Resume(id uint64) error {
+	return sf.ResumeContext(context.Background(), id)
+}
+
+// ResumeContext is Resume with a caller-supplied context.
+func (sf *Stockfighter) ResumeContext(ctx context.Context, id uint64) error {
 	var resp response
 	url := gmUrl("instances/%d/resume", id)
-	return sf.do("POST", url, nil, &resp)
+	return sf.do(ctx, "POST", url, nil, &resp)
 }
 
 // Stop a level using the instance id from a previously started Game.
 func (sf *Stockfighter) Stop(id uint64) error {
+	return sf.StopContext(context.Background(), id)
+}
+
+// StopContext is Stop with a caller-supplied context.
+func (sf *Stockfighter) StopContext(ctx context.Context, id uint64) error {
 	var resp response
 	url := gmUrl("instances/%d/stop", id)
-	return sf.do("POST", url, nil, &resp)
+	return sf.do(ctx, "POST", url, nil, &resp)
 }
 
 // Get the GameState using the instance id from a previously started Game.
 func (sf *Stockfighter) GameStatus(id uint64) (*GameState, error) {
+	return sf.GameStatusContext(context.Background(), id)
+}
+
+// GameStatusContext is GameStatus with a caller-supplied context.
+func (sf *Stockfighter) GameStatusContext(ctx context.Context, id uint64) (*GameState, error) {
 	var resp gameStateResponse
 	url := gmUrl("instances/%d", id)
-	if err := sf.do("GET", url, nil, &resp); err != nil {
+	if err := sf.do(ctx, "GET", url, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.GameState, nil
 }
 
 func (sf *Stockfighter) Judge(id uint64, evidence *Evidence) (*GameState, error) {
+	return sf.JudgeContext(context.Background(), id, evidence)
+}
+
+// JudgeContext is Judge with a caller-supplied context.
+func (sf *Stockfighter) JudgeContext(ctx context.Context, id uint64, evidence *Evidence) (*GameState, error) {
 	body, err := encodeJson(evidence)
 	if err != nil {
 		return nil, err
 	}
 	var resp gameStateResponse
 	url := gmUrl("instances/%d/judge", id)
-	if err := sf.do("POST", url, body, &resp); err != nil {
+	if err := sf.do(ctx, "POST", url, body, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.GameState, nil
-
 }
 
 func encodeJson(v interface{}) (io.Reader, error) {
@@ -321,8 +649,8 @@ func encodeJson(v interface{}) (io.Reader, error) {
 	return &buf, nil
 }
 
-func (sf *Stockfighter) do(method, url string, body io.Reader, value apiCall) error {
-	req, err := http.NewRequest(method, url, body)
+func (sf *Stockfighter) do(ctx context.Context, method, url string, body io.Reader, value apiCall) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return err
 	}
@@ -331,7 +659,7 @@ func (sf *Stockfighter) do(method, url string, body io.Reader, value apiCall) er
 		out, _ := httputil.DumpRequest(req, true)
 		log.Println(string(out))
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := sf.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -349,13 +677,20 @@ func (sf *Stockfighter) do(method, url string, body io.Reader, value apiCall) er
 	return value.Err()
 }
 
-func (sf *Stockfighter) pump(url string, f func(*websocket.Conn) error) error {
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+// pump dials url and runs f in a goroutine for as long as it keeps returning
+// nil. Canceling ctx closes the connection, which unblocks f's read with an
+// error and ends the goroutine.
+func (sf *Stockfighter) pump(ctx context.Context, url string, f func(*websocket.Conn) error) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
 	if err != nil {
 		return err
 	}
 	go func() {
 		defer conn.Close()
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
 		for err := f(conn); err == nil; err = f(conn) {
 		}
 	}()
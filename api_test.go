@@ -21,6 +21,15 @@ func checkErr(t *testing.T, desc string, err error) {
 	}
 }
 
+func TestFillSide(t *testing.T) {
+	if !fillSide("sell") {
+		t.Error(`fillSide("sell") = false, want true: a sell order hits the resting bid`)
+	}
+	if fillSide("buy") {
+		t.Error(`fillSide("buy") = true, want false: a buy order lifts the resting ask`)
+	}
+}
+
 func TestUnauthenticated(t *testing.T) {
 	sf := NewStockfighter(*apiKey, *debug)
 
@@ -0,0 +1,315 @@
+// Package arb implements a bounded-cycle, multi-leg arbitrage scanner driven
+// by a live stream of top-of-book quotes.
+//
+// The caller supplies the cycles to evaluate (Paths) rather than asking the
+// scanner to discover them itself; each quote tick only re-evaluates the
+// paths that touch the symbol that just moved, so a tick costs O(legs) per
+// affected path rather than a search over the whole graph.
+package arb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/donovanhide/stockfighter"
+)
+
+// Leg is one step of an arbitrage Path: trade Stock on Venue in Direction
+// ("buy" or "sell").
+type Leg struct {
+	Venue     string
+	Stock     string
+	Direction string
+}
+
+// Path is an ordered cycle of Legs whose net effect returns to the starting
+// inventory.
+type Path []Leg
+
+// Quote is the top-of-book data a leg is evaluated against.
+type Quote struct {
+	Bid, BidSize uint64
+	Ask, AskSize uint64
+}
+
+// Opportunity is reported when a Path's expected round-trip profit after
+// fees exceeds the scanner's MinSpreadRatio.
+type Opportunity struct {
+	Path   Path
+	Profit float64
+	Ratio  float64
+}
+
+// Graph tracks the tradable pairs a set of Paths moves through, letting a
+// Path be validated as an actual cycle before it's handed to NewScanner.
+type Graph struct {
+	edges map[string]map[string]bool
+}
+
+// NewGraph returns an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{edges: make(map[string]map[string]bool)}
+}
+
+// AddEdge records that asset a is tradable for asset b, and vice versa.
+func (g *Graph) AddEdge(a, b string) {
+	if g.edges[a] == nil {
+		g.edges[a] = make(map[string]bool)
+	}
+	if g.edges[b] == nil {
+		g.edges[b] = make(map[string]bool)
+	}
+	g.edges[a][b] = true
+	g.edges[b][a] = true
+}
+
+// Closes reports whether path forms a cycle over g: every consecutive pair
+// of legs, including the last back to the first, must be a known edge.
+func (g *Graph) Closes(path Path) bool {
+	if len(path) < 2 {
+		return false
+	}
+	for i := range path {
+		a, b := path[i].Stock, path[(i+1)%len(path)].Stock
+		if !g.edges[a][b] {
+			return false
+		}
+	}
+	return true
+}
+
+// Scanner evaluates a fixed set of Paths against the latest cached quote for
+// each leg, firing an Opportunity whenever a path clears MinSpreadRatio
+// after fees.
+type Scanner struct {
+	mu             sync.Mutex
+	paths          []Path
+	quotes         map[string]Quote
+	minSpreadRatio float64
+	feeRatio       float64
+	limits         map[string]int64
+	positions      map[string]int64
+	opportunities  chan Opportunity
+	place          func(Leg, uint64, uint64) error
+}
+
+// Option configures a Scanner constructed with NewScanner.
+type Option func(*Scanner)
+
+// WithMinSpreadRatio sets the minimum profit-after-fees ratio, expressed as
+// a fraction of notional, required before an Opportunity fires.
+func WithMinSpreadRatio(ratio float64) Option {
+	return func(s *Scanner) { s.minSpreadRatio = ratio }
+}
+
+// WithFeeRatio sets the round-trip fee charged as a fraction of notional,
+// subtracted from a path's raw spread before it's compared to
+// MinSpreadRatio.
+func WithFeeRatio(ratio float64) Option {
+	return func(s *Scanner) { s.feeRatio = ratio }
+}
+
+// WithPositionLimit caps the net quantity the scanner will accumulate in
+// stock, guarding against a runaway loop building unbounded inventory
+// during a level.
+func WithPositionLimit(stock string, max int64) Option {
+	return func(s *Scanner) { s.limits[stock] = max }
+}
+
+// WithPlacer wires the scanner to actually trade: whenever an Opportunity
+// fires, place is called once per leg in path order, and the scan stops
+// placing further legs of that path on the first error.
+func WithPlacer(place func(leg Leg, price, qty uint64) error) Option {
+	return func(s *Scanner) { s.place = place }
+}
+
+// NewScanner returns a Scanner that evaluates paths on every Update.
+func NewScanner(paths []Path, opts ...Option) *Scanner {
+	s := &Scanner{
+		paths:         paths,
+		quotes:        make(map[string]Quote),
+		limits:        make(map[string]int64),
+		positions:     make(map[string]int64),
+		opportunities: make(chan Opportunity, 16),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Opportunities returns the channel Opportunities are published to.
+func (s *Scanner) Opportunities() <-chan Opportunity {
+	return s.opportunities
+}
+
+func legKey(venue, stock string) string {
+	return venue + "/" + stock
+}
+
+// Update feeds a fresh top-of-book quote for (venue, stock) into the
+// scanner's cache and re-evaluates every path with a leg on that symbol.
+// Safe to call concurrently from multiple venues' quote streams, as Watch
+// does.
+func (s *Scanner) Update(venue, stock string, quote Quote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[legKey(venue, stock)] = quote
+	for _, path := range s.paths {
+		if pathTouches(path, venue, stock) {
+			s.evaluate(path)
+		}
+	}
+}
+
+func pathTouches(path Path, venue, stock string) bool {
+	for _, leg := range path {
+		if leg.Venue == venue && leg.Stock == stock {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate computes a path's executable round-trip P&L from the cached
+// quotes for each leg. It fires an Opportunity, and submits each leg via the
+// configured Placer, once the path clears MinSpreadRatio after fees and
+// every leg's projected position stays within its limit.
+//
+// Must be called with s.mu held.
+func (s *Scanner) evaluate(path Path) {
+	notional, proceeds := 1.0, 1.0
+	size := ^uint64(0)
+	quotes := make(map[Leg]Quote, len(path))
+	for _, leg := range path {
+		quote, ok := s.quotes[legKey(leg.Venue, leg.Stock)]
+		if !ok {
+			return
+		}
+		quotes[leg] = quote
+		switch leg.Direction {
+		case "buy":
+			if quote.Ask == 0 {
+				return
+			}
+			notional *= float64(quote.Ask)
+			size = min(size, quote.AskSize)
+		case "sell":
+			if quote.Bid == 0 {
+				return
+			}
+			proceeds *= float64(quote.Bid)
+			size = min(size, quote.BidSize)
+		default:
+			return
+		}
+	}
+	if size == 0 {
+		return
+	}
+	ratio := (proceeds-notional)/notional - s.feeRatio
+	if ratio <= s.minSpreadRatio {
+		return
+	}
+	for _, leg := range path {
+		if limit, ok := s.limits[leg.Stock]; ok {
+			if projected := abs(s.positions[leg.Stock] + signedQty(leg, size)); projected > limit {
+				return
+			}
+		}
+	}
+
+	select {
+	case s.opportunities <- Opportunity{Path: path, Profit: proceeds - notional, Ratio: ratio}:
+	default:
+	}
+
+	if s.place == nil {
+		return
+	}
+	for _, leg := range path {
+		quote := quotes[leg]
+		price := quote.Ask
+		if leg.Direction == "sell" {
+			price = quote.Bid
+		}
+		if err := s.place(leg, price, size); err != nil {
+			return
+		}
+		s.positions[leg.Stock] += signedQty(leg, size)
+	}
+}
+
+func signedQty(leg Leg, qty uint64) int64 {
+	if leg.Direction == "sell" {
+		return -int64(qty)
+	}
+	return int64(qty)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Placer returns a placer function for WithPlacer that submits each leg as
+// an immediate-or-cancel order through sf.
+func Placer(sf *stockfighter.Stockfighter, account string) func(Leg, uint64, uint64) error {
+	return func(leg Leg, price, qty uint64) error {
+		_, err := sf.Place(&stockfighter.Order{
+			Account:   account,
+			Venue:     leg.Venue,
+			Stock:     leg.Stock,
+			Price:     price,
+			Quantity:  qty,
+			Direction: leg.Direction,
+			OrderType: stockfighter.ImmediateOrCancel,
+		})
+		return err
+	}
+}
+
+// Watch subscribes to the quote stream for every venue referenced by s's
+// paths and feeds each quote into s.Update until ctx is canceled.
+func Watch(ctx context.Context, sf *stockfighter.Stockfighter, account string, s *Scanner) error {
+	for _, venue := range venuesIn(s.paths) {
+		quotes, err := sf.QuotesContext(ctx, account, venue, "")
+		if err != nil {
+			return err
+		}
+		go func(venue string, quotes chan *stockfighter.Quote) {
+			for quote := range quotes {
+				s.Update(venue, quote.Symbol, Quote{
+					Bid:     quote.Bid,
+					BidSize: quote.BidSize,
+					Ask:     quote.Ask,
+					AskSize: quote.AskSize,
+				})
+			}
+		}(venue, quotes)
+	}
+	return nil
+}
+
+func venuesIn(paths []Path) []string {
+	seen := make(map[string]bool)
+	var venues []string
+	for _, path := range paths {
+		for _, leg := range path {
+			if !seen[leg.Venue] {
+				seen[leg.Venue] = true
+				venues = append(venues, leg.Venue)
+			}
+		}
+	}
+	return venues
+}
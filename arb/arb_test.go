@@ -0,0 +1,144 @@
+package arb
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestScannerFiresOpportunityAboveMinSpreadRatio(t *testing.T) {
+	path := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "FOO", Direction: "sell"},
+	}
+	s := NewScanner([]Path{path}, WithMinSpreadRatio(0.01))
+
+	s.Update("VENUE1", "FOO", Quote{Ask: 100, AskSize: 10})
+	select {
+	case opp := <-s.Opportunities():
+		t.Fatalf("unexpected opportunity before both legs quoted: %+v", opp)
+	default:
+	}
+
+	s.Update("VENUE2", "FOO", Quote{Bid: 105, BidSize: 10})
+	select {
+	case opp := <-s.Opportunities():
+		if opp.Profit != 5 {
+			t.Fatalf("Profit = %v, want 5", opp.Profit)
+		}
+		wantRatio := 5.0 / 100.0
+		if opp.Ratio != wantRatio {
+			t.Fatalf("Ratio = %v, want %v", opp.Ratio, wantRatio)
+		}
+	default:
+		t.Fatal("expected an opportunity once both legs are quoted")
+	}
+}
+
+func TestScannerSuppressesBelowMinSpreadRatio(t *testing.T) {
+	path := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "FOO", Direction: "sell"},
+	}
+	s := NewScanner([]Path{path}, WithMinSpreadRatio(0.5))
+	s.Update("VENUE1", "FOO", Quote{Ask: 100, AskSize: 10})
+	s.Update("VENUE2", "FOO", Quote{Bid: 105, BidSize: 10})
+
+	select {
+	case opp := <-s.Opportunities():
+		t.Fatalf("unexpected opportunity below MinSpreadRatio: %+v", opp)
+	default:
+	}
+}
+
+func TestScannerFeeRatioReducesSpread(t *testing.T) {
+	path := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "FOO", Direction: "sell"},
+	}
+	s := NewScanner([]Path{path}, WithMinSpreadRatio(0.04), WithFeeRatio(0.02))
+	s.Update("VENUE1", "FOO", Quote{Ask: 100, AskSize: 10})
+	s.Update("VENUE2", "FOO", Quote{Bid: 105, BidSize: 10})
+
+	select {
+	case opp := <-s.Opportunities():
+		t.Fatalf("fee ratio should have pushed this below MinSpreadRatio: %+v", opp)
+	default:
+	}
+}
+
+func TestScannerHonoursPositionLimit(t *testing.T) {
+	path := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "FOO", Direction: "sell"},
+	}
+	var placed int
+	s := NewScanner([]Path{path},
+		WithMinSpreadRatio(0.01),
+		WithPositionLimit("FOO", 5),
+		WithPlacer(func(leg Leg, price, qty uint64) error {
+			placed++
+			return nil
+		}),
+	)
+	s.Update("VENUE1", "FOO", Quote{Ask: 100, AskSize: 10})
+	s.Update("VENUE2", "FOO", Quote{Bid: 105, BidSize: 10})
+
+	if placed != 0 {
+		t.Fatalf("expected the position limit (5) to block a size-10 trade, got %d placements", placed)
+	}
+}
+
+// TestGraphClosesValidatesPathIsACycle demonstrates the intended use of
+// Graph: a caller builds it from the tradable pairs it knows about, then
+// uses Closes to reject a Path with a leg that doesn't actually connect
+// back to the rest of the cycle, before ever handing it to NewScanner.
+func TestGraphClosesValidatesPathIsACycle(t *testing.T) {
+	g := NewGraph()
+	g.AddEdge("FOO", "BAR")
+	g.AddEdge("BAR", "FOO")
+
+	closed := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "BAR", Direction: "sell"},
+	}
+	if !g.Closes(closed) {
+		t.Fatalf("Closes(%+v) = false, want true: every consecutive pair, including wraparound, is a known edge", closed)
+	}
+
+	broken := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "BAZ", Direction: "sell"},
+	}
+	if g.Closes(broken) {
+		t.Fatalf("Closes(%+v) = true, want false: BAZ is not a known edge of FOO", broken)
+	}
+
+	if g.Closes(Path{{Venue: "VENUE1", Stock: "FOO", Direction: "buy"}}) {
+		t.Fatal("Closes() = true for a single-leg path, want false: a cycle needs at least two legs")
+	}
+}
+
+// TestScannerConcurrentUpdate exercises the exact shape Watch produces: one
+// goroutine per venue calling Update concurrently for a path with legs on
+// more than one venue. Run with -race to catch concurrent map access.
+func TestScannerConcurrentUpdate(t *testing.T) {
+	path := Path{
+		{Venue: "VENUE1", Stock: "FOO", Direction: "buy"},
+		{Venue: "VENUE2", Stock: "FOO", Direction: "sell"},
+	}
+	s := NewScanner([]Path{path}, WithMinSpreadRatio(0.01))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			s.Update("VENUE1", "FOO", Quote{Ask: uint64(100 + i%3), AskSize: 10})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			s.Update("VENUE2", "FOO", Quote{Bid: uint64(105 + i%3), BidSize: 10})
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,215 @@
+// Package orderbook maintains a reconciled, in-memory L2 order book for a
+// single instrument, built from a REST snapshot plus a live stream of fills.
+//
+// The Book itself knows nothing about venues, accounts or wire formats; it
+// is driven by ApplySnapshot and ApplyFill. See Stockfighter.WatchOrderBook
+// for the glue that feeds a Book from the Stockfighter API.
+package orderbook
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoResyncFunc is returned by Resync when no resync function has been
+// configured with SetResyncFunc.
+var ErrNoResyncFunc = errors.New("orderbook: no resync function configured")
+
+// Level is a single aggregated price level: the total resting quantity at Price.
+type Level struct {
+	Price    uint64
+	Quantity uint64
+}
+
+// BookEvent is published on a Book's Subscribe channel whenever the book changes.
+type BookEvent struct {
+	Time time.Time
+	Bids []Level
+	Asks []Level
+}
+
+// Book is a live, reconciled L2 order book for a single instrument.
+//
+// The zero value is not usable; create one with New. A Book is safe for
+// concurrent use.
+type Book struct {
+	mu       sync.Mutex
+	bids     map[uint64]uint64
+	asks     map[uint64]uint64
+	ts       time.Time
+	snapTs   time.Time
+	subs     []chan BookEvent
+	resyncFn func() error
+}
+
+// New returns an empty Book. Call ApplySnapshot before relying on BestBid,
+// BestAsk, Spread or Levels.
+func New() *Book {
+	return &Book{
+		bids: make(map[uint64]uint64),
+		asks: make(map[uint64]uint64),
+	}
+}
+
+// ApplySnapshot replaces the book's state with a REST snapshot taken at ts,
+// discarding anything applied before it. The invariant callers should
+// maintain is: after ApplySnapshot(ts) returns, re-apply every fill with a
+// timestamp after ts so none is lost and none is double-counted.
+func (b *Book) ApplySnapshot(bids, asks []Level, ts time.Time) {
+	b.mu.Lock()
+	b.bids = levelMap(bids)
+	b.asks = levelMap(asks)
+	b.ts = ts
+	b.snapTs = ts
+	b.mu.Unlock()
+	b.publish()
+}
+
+// SetResyncFunc configures the function Resync calls to refetch and apply a
+// fresh snapshot. Callers that get a Book from Stockfighter.WatchOrderBook
+// never need to call this themselves; it is already wired up.
+func (b *Book) SetResyncFunc(fn func() error) {
+	b.mu.Lock()
+	b.resyncFn = fn
+	b.mu.Unlock()
+}
+
+// Resync re-fetches the snapshot via the function set with SetResyncFunc and
+// applies it, discarding any state older than the new snapshot. Callers
+// that detect a gap themselves (a reconnect, a missed sequence number) can
+// call this directly rather than waiting for the book to notice on its own.
+func (b *Book) Resync() error {
+	b.mu.Lock()
+	fn := b.resyncFn
+	b.mu.Unlock()
+	if fn == nil {
+		return ErrNoResyncFunc
+	}
+	return fn()
+}
+
+func levelMap(levels []Level) map[uint64]uint64 {
+	m := make(map[uint64]uint64, len(levels))
+	for _, l := range levels {
+		m[l.Price] = l.Quantity
+	}
+	return m
+}
+
+// ApplyFill reduces the resting quantity at price on the given side by qty,
+// removing the level entirely once it reaches zero. Fills at or before the
+// timestamp of the last ApplySnapshot are ignored, so a Resync can be
+// followed by blindly replaying buffered fills without double-applying any
+// of them.
+func (b *Book) ApplyFill(isBid bool, price, qty uint64, ts time.Time) {
+	b.mu.Lock()
+	if !ts.After(b.snapTs) {
+		b.mu.Unlock()
+		return
+	}
+	side := b.asks
+	if isBid {
+		side = b.bids
+	}
+	if remaining, ok := side[price]; ok {
+		if qty >= remaining {
+			delete(side, price)
+		} else {
+			side[price] = remaining - qty
+		}
+	}
+	b.ts = ts
+	b.mu.Unlock()
+	b.publish()
+}
+
+// BestBid returns the highest resting bid price and its quantity.
+func (b *Book) BestBid() (price, quantity uint64, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return best(b.bids, true)
+}
+
+// BestAsk returns the lowest resting ask price and its quantity.
+func (b *Book) BestAsk() (price, quantity uint64, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return best(b.asks, false)
+}
+
+func best(side map[uint64]uint64, highest bool) (price, quantity uint64, ok bool) {
+	first := true
+	for p, q := range side {
+		if first || (highest && p > price) || (!highest && p < price) {
+			price, quantity, first = p, q, false
+		}
+	}
+	return price, quantity, !first
+}
+
+// Spread returns BestAsk - BestBid. ok is false until both sides have at
+// least one resting level, and also while the book is crossed (best ask
+// below best bid).
+func (b *Book) Spread() (spread uint64, ok bool) {
+	bid, _, bidOk := b.BestBid()
+	ask, _, askOk := b.BestAsk()
+	if !bidOk || !askOk || ask < bid {
+		return 0, false
+	}
+	return ask - bid, true
+}
+
+// Levels returns up to n price levels per side, best price first. n < 0
+// returns every level.
+func (b *Book) Levels(n int) (bids, asks []Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return sortedLevels(b.bids, n, true), sortedLevels(b.asks, n, false)
+}
+
+func sortedLevels(side map[uint64]uint64, n int, descending bool) []Level {
+	levels := make([]Level, 0, len(side))
+	for p, q := range side {
+		levels = append(levels, Level{Price: p, Quantity: q})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].Price > levels[j].Price
+		}
+		return levels[i].Price < levels[j].Price
+	})
+	if n >= 0 && len(levels) > n {
+		levels = levels[:n]
+	}
+	return levels
+}
+
+// Subscribe returns a channel of BookEvents published on every ApplySnapshot
+// or ApplyFill. The channel is buffered but never closed; a slow reader
+// misses intermediate events rather than blocking the book.
+func (b *Book) Subscribe() <-chan BookEvent {
+	c := make(chan BookEvent, 16)
+	b.mu.Lock()
+	b.subs = append(b.subs, c)
+	b.mu.Unlock()
+	return c
+}
+
+func (b *Book) publish() {
+	b.mu.Lock()
+	event := BookEvent{
+		Time: b.ts,
+		Bids: sortedLevels(b.bids, -1, true),
+		Asks: sortedLevels(b.asks, -1, false),
+	}
+	subs := b.subs
+	b.mu.Unlock()
+	for _, c := range subs {
+		select {
+		case c <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplySnapshotThenFillReconciles(t *testing.T) {
+	b := New()
+	t0 := time.Unix(100, 0)
+	b.ApplySnapshot(
+		[]Level{{Price: 99, Quantity: 10}},
+		[]Level{{Price: 101, Quantity: 10}},
+		t0,
+	)
+
+	b.ApplyFill(true, 99, 4, t0.Add(time.Second))
+	if price, qty, ok := b.BestBid(); !ok || price != 99 || qty != 6 {
+		t.Fatalf("BestBid = (%d, %d, %v), want (99, 6, true)", price, qty, ok)
+	}
+
+	b.ApplyFill(true, 99, 6, t0.Add(2*time.Second))
+	if _, _, ok := b.BestBid(); ok {
+		t.Fatal("expected bid level to be removed once fully depleted")
+	}
+}
+
+func TestApplyFillIgnoresStaleEvents(t *testing.T) {
+	b := New()
+	t0 := time.Unix(200, 0)
+	b.ApplySnapshot([]Level{{Price: 50, Quantity: 10}}, nil, t0)
+
+	// A fill timestamped at-or-before the snapshot must be ignored: it is
+	// exactly the kind of event a Resync's buffered replay would otherwise
+	// double-apply.
+	b.ApplyFill(true, 50, 10, t0)
+	b.ApplyFill(true, 50, 10, t0.Add(-time.Second))
+
+	if price, qty, ok := b.BestBid(); !ok || price != 50 || qty != 10 {
+		t.Fatalf("BestBid = (%d, %d, %v), want (50, 10, true); stale fill should not have applied", price, qty, ok)
+	}
+}
+
+func TestApplySnapshotSupersedesOlderFills(t *testing.T) {
+	b := New()
+	t0 := time.Unix(300, 0)
+	b.ApplySnapshot([]Level{{Price: 10, Quantity: 5}}, nil, t0)
+	b.ApplyFill(true, 10, 3, t0.Add(time.Second))
+
+	// A fresh snapshot taken later than the last applied event must win,
+	// even if it reports more quantity than the old state: it is the
+	// current ground truth, not a delta.
+	b.ApplySnapshot([]Level{{Price: 10, Quantity: 50}}, nil, t0.Add(time.Minute))
+	if price, qty, ok := b.BestBid(); !ok || price != 10 || qty != 50 {
+		t.Fatalf("BestBid = (%d, %d, %v), want (10, 50, true)", price, qty, ok)
+	}
+}
+
+func TestSpread(t *testing.T) {
+	b := New()
+	if _, ok := b.Spread(); ok {
+		t.Fatal("Spread should report false before both sides are known")
+	}
+	b.ApplySnapshot([]Level{{Price: 99, Quantity: 1}}, []Level{{Price: 101, Quantity: 1}}, time.Unix(1, 0))
+	spread, ok := b.Spread()
+	if !ok || spread != 2 {
+		t.Fatalf("Spread() = (%d, %v), want (2, true)", spread, ok)
+	}
+}
+
+func TestResyncCallsConfiguredFunc(t *testing.T) {
+	b := New()
+	if err := b.Resync(); !errors.Is(err, ErrNoResyncFunc) {
+		t.Fatalf("Resync() with no func configured = %v, want ErrNoResyncFunc", err)
+	}
+
+	var calls int
+	b.SetResyncFunc(func() error {
+		calls++
+		b.ApplySnapshot([]Level{{Price: 1, Quantity: 1}}, nil, time.Unix(int64(calls), 0))
+		return nil
+	})
+	if err := b.Resync(); err != nil {
+		t.Fatalf("Resync() = %v, want nil", err)
+	}
+	if calls != 1 {
+		t.Fatalf("resync func called %d times, want 1", calls)
+	}
+}
+
+func TestLevelsOrdering(t *testing.T) {
+	b := New()
+	b.ApplySnapshot(
+		[]Level{{Price: 10, Quantity: 1}, {Price: 12, Quantity: 1}, {Price: 11, Quantity: 1}},
+		[]Level{{Price: 20, Quantity: 1}, {Price: 18, Quantity: 1}, {Price: 19, Quantity: 1}},
+		time.Unix(1, 0),
+	)
+	bids, asks := b.Levels(2)
+	if len(bids) != 2 || bids[0].Price != 12 || bids[1].Price != 11 {
+		t.Fatalf("bids = %+v, want best-first [12, 11]", bids)
+	}
+	if len(asks) != 2 || asks[0].Price != 18 || asks[1].Price != 19 {
+		t.Fatalf("asks = %+v, want best-first [18, 19]", asks)
+	}
+}
@@ -0,0 +1,114 @@
+package stockfighter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func jsonRoundTripper(body string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}
+}
+
+func newStockfighterWithTransport(rt http.RoundTripper) *Stockfighter {
+	sf := NewStockfighter("test-key", false)
+	sf.client = &http.Client{Transport: rt}
+	return sf
+}
+
+func TestPlaceContextRejectsInvalidTickSize(t *testing.T) {
+	sf := newStockfighterWithTransport(jsonRoundTripper(`{"ok":true,"symbols":[{"symbol":"FOO"}]}`))
+	sf.SetSymbolInfo(&SymbolInfo{Venue: "TESTEX", Symbol: "FOO", PriceTick: 5, LotSize: 1})
+
+	_, err := sf.PlaceContext(context.Background(), &Order{Venue: "TESTEX", Stock: "FOO", Price: 12, Quantity: 1})
+	if err != ErrInvalidTickSize {
+		t.Fatalf("PlaceContext() error = %v, want ErrInvalidTickSize", err)
+	}
+}
+
+func TestPlaceContextRejectsInvalidLotSize(t *testing.T) {
+	sf := newStockfighterWithTransport(jsonRoundTripper(`{"ok":true,"symbols":[{"symbol":"FOO"}]}`))
+	sf.SetSymbolInfo(&SymbolInfo{Venue: "TESTEX", Symbol: "FOO", PriceTick: 1, LotSize: 10})
+
+	_, err := sf.PlaceContext(context.Background(), &Order{Venue: "TESTEX", Stock: "FOO", Price: 1, Quantity: 3})
+	if err != ErrInvalidLotSize {
+		t.Fatalf("PlaceContext() error = %v, want ErrInvalidLotSize", err)
+	}
+}
+
+func TestPlaceContextAcceptsValidOrder(t *testing.T) {
+	var orderRequests int
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		orderRequests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true,"id":42}`)),
+		}, nil
+	})
+	sf := newStockfighterWithTransport(rt)
+	sf.SetSymbolInfo(&SymbolInfo{Venue: "TESTEX", Symbol: "FOO", PriceTick: 5, LotSize: 10})
+
+	state, err := sf.PlaceContext(context.Background(), &Order{Venue: "TESTEX", Stock: "FOO", Price: 10, Quantity: 20})
+	if err != nil {
+		t.Fatalf("PlaceContext() error = %v", err)
+	}
+	if state.Id != 42 {
+		t.Fatalf("PlaceContext() Id = %d, want 42", state.Id)
+	}
+	if orderRequests != 1 {
+		t.Fatalf("order requests = %d, want 1", orderRequests)
+	}
+}
+
+func TestSymbolInfoContextCachesAcrossCalls(t *testing.T) {
+	var stocksRequests int
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		stocksRequests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true,"symbols":[{"symbol":"FOO"}]}`)),
+		}, nil
+	})
+	sf := newStockfighterWithTransport(rt)
+
+	first, err := sf.SymbolInfoContext(context.Background(), "TESTEX", "FOO")
+	if err != nil {
+		t.Fatalf("SymbolInfoContext() error = %v", err)
+	}
+	second, err := sf.SymbolInfoContext(context.Background(), "TESTEX", "FOO")
+	if err != nil {
+		t.Fatalf("SymbolInfoContext() error = %v", err)
+	}
+	if first != second {
+		t.Fatalf("SymbolInfoContext() returned different *SymbolInfo on the second call, want the cached one")
+	}
+	if stocksRequests != 1 {
+		t.Fatalf("stocks requests = %d, want 1 (second call should hit the cache)", stocksRequests)
+	}
+}
+
+func TestSetSymbolInfoOverridesCache(t *testing.T) {
+	sf := newStockfighterWithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("SetSymbolInfo should make the Stocks round trip unnecessary")
+		return nil, nil
+	}))
+	sf.SetSymbolInfo(&SymbolInfo{Venue: "TESTEX", Symbol: "FOO", PriceTick: 2, LotSize: 1, QuoteCurrency: "USD"})
+
+	info, err := sf.SymbolInfoContext(context.Background(), "TESTEX", "FOO")
+	if err != nil {
+		t.Fatalf("SymbolInfoContext() error = %v", err)
+	}
+	if info.QuoteCurrency != "USD" {
+		t.Fatalf("SymbolInfoContext() QuoteCurrency = %q, want USD", info.QuoteCurrency)
+	}
+}
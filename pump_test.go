@@ -0,0 +1,87 @@
+package stockfighter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestPumpEndsOnContextCancelDuringBlockedSend reproduces the leak the
+// chunk0-3 review called out: a server that bursts messages faster than the
+// caller drains them, canceled right after the caller stops reading (the
+// obvious shutdown pattern). Before the fix, the pump goroutine was left
+// blocked forever trying to hand the second message to an unbuffered
+// channel nobody was draining; canceling ctx only closed the connection,
+// which does nothing for a goroutine already past the read. This test never
+// reads from c a second time, so the only way the second callback
+// invocation can return is by noticing ctx is done, exactly as a caller
+// that stops draining on cancel would see in practice.
+func TestPumpEndsOnContextCancelDuringBlockedSend(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for i := 0; i < 2; i++ {
+			msg, _ := json.Marshal(quoteMessage{Ok: true, Quote: Quote{Bid: uint64(100 + i)}})
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		}
+		<-release
+	}))
+	defer func() {
+		close(release)
+		server.Close()
+	}()
+
+	sf := NewStockfighter("", false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := make(chan *Quote)
+	var calls int32
+	secondCallReturned := make(chan struct{})
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	err := sf.pump(ctx, url, func(conn *websocket.Conn) error {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			defer close(secondCallReturned)
+		}
+		var quote quoteMessage
+		if err := sf.decodeMessage(conn, &quote); err != nil {
+			close(c)
+			return err
+		}
+		if quote.Ok {
+			select {
+			case c <- &quote.Quote:
+			case <-ctx.Done():
+				close(c)
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("pump() = %v", err)
+	}
+
+	<-c // read exactly one message, then stop draining
+	cancel()
+
+	select {
+	case <-secondCallReturned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("pump goroutine appears to still be blocked sending the second message after ctx was canceled")
+	}
+}
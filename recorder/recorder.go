@@ -0,0 +1,169 @@
+// Package recorder tees live Quote, Execution and OrderState data into a
+// pluggable Store so a trading session can be analyzed, or replayed
+// offline, after the fact.
+package recorder
+
+import (
+	"context"
+	"log"
+
+	"github.com/donovanhide/stockfighter"
+)
+
+// Store persists whatever a Recorder tees into it, in the order received,
+// and can hand every persisted Record back, in that same order, for Replay
+// to sort by timestamp. Implementations live alongside this file: NDJSONStore,
+// CSVStore and SQLiteStore.
+type Store interface {
+	WriteQuote(*stockfighter.Quote) error
+	WriteExecution(*stockfighter.Execution) error
+	WriteOrderState(*stockfighter.OrderState) error
+	ReadAll() ([]Record, error)
+	Close() error
+}
+
+// Client is the trading method set a *Recorder and a replayed *Player both
+// satisfy, so a strategy written against Client can run live or backtested
+// against a captured session unchanged.
+type Client interface {
+	Place(order *stockfighter.Order) (*stockfighter.OrderState, error)
+	PlaceContext(ctx context.Context, order *stockfighter.Order) (*stockfighter.OrderState, error)
+	Status(venue, stock string, id uint64) (*stockfighter.OrderState, error)
+	StatusContext(ctx context.Context, venue, stock string, id uint64) (*stockfighter.OrderState, error)
+	Cancel(venue, stock string, id uint64) (*stockfighter.OrderState, error)
+	CancelContext(ctx context.Context, venue, stock string, id uint64) (*stockfighter.OrderState, error)
+	StockStatus(account, venue, stock string) ([]stockfighter.OrderState, error)
+	Quotes(account, venue, stock string) (chan *stockfighter.Quote, error)
+	QuotesContext(ctx context.Context, account, venue, stock string) (chan *stockfighter.Quote, error)
+	Executions(account, venue, stock string) (chan *stockfighter.Execution, error)
+	ExecutionsContext(ctx context.Context, account, venue, stock string) (chan *stockfighter.Execution, error)
+}
+
+var _ Client = (*Recorder)(nil)
+
+// Recorder wraps a *stockfighter.Stockfighter, teeing every Quote and
+// Execution it streams, and every OrderState returned by Place, Status and
+// Cancel, into a Store before handing it back to the caller unchanged.
+// StockStatus passes through without being recorded. It satisfies Client, so code written against Client
+// can run live through a Recorder or backtested through a Player.
+type Recorder struct {
+	*stockfighter.Stockfighter
+	store Store
+}
+
+// RecordTo wraps sf so that every Quote and Execution it streams, and every
+// OrderState returned by Place, Status and Cancel, is also written to store.
+func RecordTo(sf *stockfighter.Stockfighter, store Store) *Recorder {
+	return &Recorder{Stockfighter: sf, store: store}
+}
+
+// write logs a Store write error rather than discarding it: a full disk or a
+// locked database losing events silently would defeat the whole point of
+// recording a session for later reproducibility.
+func (r *Recorder) write(err error) {
+	if err != nil {
+		log.Printf("recorder: %v", err)
+	}
+}
+
+// Place tees the resulting OrderState into the Store before returning it.
+func (r *Recorder) Place(order *stockfighter.Order) (*stockfighter.OrderState, error) {
+	state, err := r.Stockfighter.Place(order)
+	if state != nil {
+		r.write(r.store.WriteOrderState(state))
+	}
+	return state, err
+}
+
+// PlaceContext tees the resulting OrderState into the Store before returning it.
+func (r *Recorder) PlaceContext(ctx context.Context, order *stockfighter.Order) (*stockfighter.OrderState, error) {
+	state, err := r.Stockfighter.PlaceContext(ctx, order)
+	if state != nil {
+		r.write(r.store.WriteOrderState(state))
+	}
+	return state, err
+}
+
+// Status tees the resulting OrderState into the Store before returning it.
+func (r *Recorder) Status(venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	state, err := r.Stockfighter.Status(venue, stock, id)
+	if state != nil {
+		r.write(r.store.WriteOrderState(state))
+	}
+	return state, err
+}
+
+// StatusContext tees the resulting OrderState into the Store before returning it.
+func (r *Recorder) StatusContext(ctx context.Context, venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	state, err := r.Stockfighter.StatusContext(ctx, venue, stock, id)
+	if state != nil {
+		r.write(r.store.WriteOrderState(state))
+	}
+	return state, err
+}
+
+// Cancel tees the resulting OrderState into the Store before returning it.
+func (r *Recorder) Cancel(venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	state, err := r.Stockfighter.Cancel(venue, stock, id)
+	if state != nil {
+		r.write(r.store.WriteOrderState(state))
+	}
+	return state, err
+}
+
+// CancelContext tees the resulting OrderState into the Store before returning it.
+func (r *Recorder) CancelContext(ctx context.Context, venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	state, err := r.Stockfighter.CancelContext(ctx, venue, stock, id)
+	if state != nil {
+		r.write(r.store.WriteOrderState(state))
+	}
+	return state, err
+}
+
+// Quotes tees every Quote from the underlying stream into the Store before
+// forwarding it to the caller.
+func (r *Recorder) Quotes(account, venue, stock string) (chan *stockfighter.Quote, error) {
+	return r.QuotesContext(context.Background(), account, venue, stock)
+}
+
+// QuotesContext tees every Quote from the underlying stream into the Store
+// before forwarding it to the caller.
+func (r *Recorder) QuotesContext(ctx context.Context, account, venue, stock string) (chan *stockfighter.Quote, error) {
+	quotes, err := r.Stockfighter.QuotesContext(ctx, account, venue, stock)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan *stockfighter.Quote)
+	go func() {
+		defer close(out)
+		for quote := range quotes {
+			r.write(r.store.WriteQuote(quote))
+			out <- quote
+		}
+	}()
+	return out, nil
+}
+
+// Executions tees every Execution from the underlying stream into the Store
+// before forwarding it to the caller.
+func (r *Recorder) Executions(account, venue, stock string) (chan *stockfighter.Execution, error) {
+	return r.ExecutionsContext(context.Background(), account, venue, stock)
+}
+
+// ExecutionsContext tees every Execution from the underlying stream into the
+// Store before forwarding it to the caller.
+func (r *Recorder) ExecutionsContext(ctx context.Context, account, venue, stock string) (chan *stockfighter.Execution, error) {
+	executions, err := r.Stockfighter.ExecutionsContext(ctx, account, venue, stock)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan *stockfighter.Execution)
+	go func() {
+		defer close(out)
+		for execution := range executions {
+			r.write(r.store.WriteExecution(execution))
+			out <- execution
+		}
+	}()
+	return out, nil
+}
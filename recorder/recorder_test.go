@@ -0,0 +1,129 @@
+package recorder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donovanhide/stockfighter"
+)
+
+func TestNDJSONStoreRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewNDJSONStore(&buf)
+
+	q := &stockfighter.Quote{Venue: "TESTEX", Symbol: "FOO", Bid: 100, QuoteTime: time.Unix(1, 0)}
+	if err := store.WriteQuote(q); err != nil {
+		t.Fatalf("WriteQuote() = %v", err)
+	}
+
+	records, err := store.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() = %v", err)
+	}
+	if len(records) != 1 || records[0].Quote.Bid != 100 {
+		t.Fatalf("ReadAll() = %+v, want one quote record with Bid 100", records)
+	}
+
+	fromDisk, err := ReadNDJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadNDJSON() = %v", err)
+	}
+	if len(fromDisk) != 1 || fromDisk[0].Quote.Bid != 100 {
+		t.Fatalf("ReadNDJSON() = %+v, want one quote record with Bid 100", fromDisk)
+	}
+}
+
+func TestReplayServesRecordsInTimestampOrder(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewNDJSONStore(&buf)
+
+	checkErr := func(err error) {
+		if err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	checkErr(store.WriteQuote(&stockfighter.Quote{Venue: "TESTEX", Symbol: "FOO", Bid: 102, QuoteTime: time.Unix(2, 0)}))
+	checkErr(store.WriteQuote(&stockfighter.Quote{Venue: "TESTEX", Symbol: "FOO", Bid: 101, QuoteTime: time.Unix(1, 0)}))
+
+	player, err := Replay(store)
+	if err != nil {
+		t.Fatalf("Replay() = %v", err)
+	}
+
+	quotes, err := player.Quotes("ACC", "TESTEX", "FOO")
+	if err != nil {
+		t.Fatalf("Quotes() = %v", err)
+	}
+	first := <-quotes
+	second := <-quotes
+	if first.Bid != 101 || second.Bid != 102 {
+		t.Fatalf("replayed bids = [%d, %d], want [101, 102] in timestamp order", first.Bid, second.Bid)
+	}
+	if _, ok := <-quotes; ok {
+		t.Fatal("expected channel to close once exhausted")
+	}
+}
+
+func TestCSVStoreRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewCSVStore(&buf)
+
+	q := &stockfighter.Quote{Venue: "TESTEX", Symbol: "FOO", Bid: 100, QuoteTime: time.Unix(1, 0)}
+	if err := store.WriteQuote(q); err != nil {
+		t.Fatalf("WriteQuote() = %v", err)
+	}
+
+	fromDisk, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV() = %v", err)
+	}
+	if len(fromDisk) != 1 || fromDisk[0].Quote.Bid != 100 {
+		t.Fatalf("ReadCSV() = %+v, want one quote record with Bid 100", fromDisk)
+	}
+}
+
+func TestReadCSVRejectsShortRow(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("quote,2024-01-01T00:00:00Z\n"))
+	if err == nil {
+		t.Fatal("ReadCSV() = nil error, want an error for a row missing its payload field")
+	}
+}
+
+func TestPlayerPlaceConsumesRecordsInOrder(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewNDJSONStore(&buf)
+
+	checkErr := func(err error) {
+		if err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	checkErr(store.WriteOrderState(&stockfighter.OrderState{Account: "ACC", Venue: "TESTEX", Symbol: "FOO", Id: 1, Timestamp: time.Unix(1, 0)}))
+	checkErr(store.WriteOrderState(&stockfighter.OrderState{Account: "ACC", Venue: "TESTEX", Symbol: "FOO", Id: 2, Timestamp: time.Unix(2, 0)}))
+
+	player, err := Replay(store)
+	if err != nil {
+		t.Fatalf("Replay() = %v", err)
+	}
+
+	order := &stockfighter.Order{Account: "ACC", Venue: "TESTEX", Stock: "FOO"}
+	first, err := player.Place(order)
+	if err != nil {
+		t.Fatalf("Place() = %v", err)
+	}
+	if first.Id != 1 {
+		t.Fatalf("first Place() returned Id %d, want 1", first.Id)
+	}
+	second, err := player.Place(order)
+	if err != nil {
+		t.Fatalf("Place() = %v", err)
+	}
+	if second.Id != 2 {
+		t.Fatalf("second Place() returned Id %d, want 2", second.Id)
+	}
+	if _, err := player.Place(order); err != ErrReplayExhausted {
+		t.Fatalf("third Place() = %v, want ErrReplayExhausted", err)
+	}
+}
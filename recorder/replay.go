@@ -0,0 +1,189 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donovanhide/stockfighter"
+)
+
+// Record is one persisted event read back from a Store: exactly one of
+// Quote, Execution or OrderState is set, matching Kind.
+type Record struct {
+	Kind       string
+	Time       time.Time
+	Quote      *stockfighter.Quote
+	Execution  *stockfighter.Execution
+	OrderState *stockfighter.OrderState
+}
+
+// ErrReplayExhausted is returned by a Player method once there is no
+// recorded event left matching the request.
+var ErrReplayExhausted = errors.New("recorder: no matching recorded event")
+
+var _ Client = (*Player)(nil)
+
+// Player replays a previously recorded session in timestamp order. It
+// satisfies Client, so a strategy written against Client can be backtested
+// against a Player exactly as it would run live against a Recorder.
+type Player struct {
+	records []Record
+
+	mu          sync.Mutex
+	placeCursor map[string]int
+}
+
+// Replay reads every Record back from store and returns a Player serving
+// them in timestamp order.
+func Replay(store Store) (*Player, error) {
+	records, err := store.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]Record, len(records))
+	copy(sorted, records)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	return &Player{records: sorted, placeCursor: make(map[string]int)}, nil
+}
+
+// Quotes replays every recorded Quote for (venue, stock) in order on a
+// channel that is closed once exhausted. account is accepted only to match
+// Stockfighter.Quotes; recorded Quotes carry no account.
+func (p *Player) Quotes(account, venue, stock string) (chan *stockfighter.Quote, error) {
+	return p.QuotesContext(context.Background(), account, venue, stock)
+}
+
+// QuotesContext is Quotes with a caller-supplied context; canceling ctx
+// stops the replay early instead of draining the rest of the session.
+func (p *Player) QuotesContext(ctx context.Context, account, venue, stock string) (chan *stockfighter.Quote, error) {
+	c := make(chan *stockfighter.Quote)
+	go func() {
+		defer close(c)
+		for _, rec := range p.records {
+			if rec.Quote == nil || rec.Quote.Venue != venue {
+				continue
+			}
+			if stock != "" && rec.Quote.Symbol != stock {
+				continue
+			}
+			select {
+			case c <- rec.Quote:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+// Executions replays every recorded Execution for (account, venue, stock) in
+// order on a channel that is closed once exhausted.
+func (p *Player) Executions(account, venue, stock string) (chan *stockfighter.Execution, error) {
+	return p.ExecutionsContext(context.Background(), account, venue, stock)
+}
+
+// ExecutionsContext is Executions with a caller-supplied context; canceling
+// ctx stops the replay early instead of draining the rest of the session.
+func (p *Player) ExecutionsContext(ctx context.Context, account, venue, stock string) (chan *stockfighter.Execution, error) {
+	c := make(chan *stockfighter.Execution)
+	go func() {
+		defer close(c)
+		for _, rec := range p.records {
+			if rec.Execution == nil || rec.Execution.Account != account || rec.Execution.Venue != venue {
+				continue
+			}
+			if stock != "" && rec.Execution.Symbol != stock {
+				continue
+			}
+			select {
+			case c <- rec.Execution:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+// StockStatus returns every recorded OrderState for (account, venue, stock),
+// in timestamp order.
+func (p *Player) StockStatus(account, venue, stock string) ([]stockfighter.OrderState, error) {
+	var states []stockfighter.OrderState
+	for _, rec := range p.records {
+		if rec.OrderState == nil || rec.OrderState.Account != account || rec.OrderState.Venue != venue {
+			continue
+		}
+		if stock != "" && rec.OrderState.Symbol != stock {
+			continue
+		}
+		states = append(states, *rec.OrderState)
+	}
+	return states, nil
+}
+
+// Place returns the next recorded OrderState for (order.Account,
+// order.Venue, order.Stock) that hasn't already been returned by a previous
+// Place call, in timestamp order, standing in for the fill the exchange
+// would have produced live. It returns ErrReplayExhausted once the session
+// has no more matching OrderStates.
+func (p *Player) Place(order *stockfighter.Order) (*stockfighter.OrderState, error) {
+	return p.PlaceContext(context.Background(), order)
+}
+
+// PlaceContext is Place with a caller-supplied context; ctx is accepted only
+// to match Stockfighter.PlaceContext, since replay never blocks on the
+// network.
+func (p *Player) PlaceContext(ctx context.Context, order *stockfighter.Order) (*stockfighter.OrderState, error) {
+	key := placeKey(order.Account, order.Venue, order.Stock)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := p.placeCursor[key]; i < len(p.records); i++ {
+		rec := p.records[i]
+		if rec.OrderState == nil || rec.OrderState.Account != order.Account ||
+			rec.OrderState.Venue != order.Venue || rec.OrderState.Symbol != order.Stock {
+			continue
+		}
+		p.placeCursor[key] = i + 1
+		return rec.OrderState, nil
+	}
+	return nil, ErrReplayExhausted
+}
+
+func placeKey(account, venue, stock string) string {
+	return account + "/" + venue + "/" + stock
+}
+
+// Status returns the latest recorded OrderState for (venue, stock, id),
+// looked up by the order id the exchange assigned when it was originally
+// recorded.
+func (p *Player) Status(venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	return p.StatusContext(context.Background(), venue, stock, id)
+}
+
+// StatusContext is Status with a caller-supplied context; ctx is accepted
+// only to match Stockfighter.StatusContext.
+func (p *Player) StatusContext(ctx context.Context, venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	for i := len(p.records) - 1; i >= 0; i-- {
+		rec := p.records[i]
+		if rec.OrderState != nil && rec.OrderState.Venue == venue && rec.OrderState.Symbol == stock && rec.OrderState.Id == id {
+			return rec.OrderState, nil
+		}
+	}
+	return nil, ErrReplayExhausted
+}
+
+// Cancel returns the recorded OrderState for (venue, stock, id), the same
+// way Status does: replay has no live order to cancel, only the state it
+// was last recorded in.
+func (p *Player) Cancel(venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	return p.Status(venue, stock, id)
+}
+
+// CancelContext is Cancel with a caller-supplied context; ctx is accepted
+// only to match Stockfighter.CancelContext.
+func (p *Player) CancelContext(ctx context.Context, venue, stock string, id uint64) (*stockfighter.OrderState, error) {
+	return p.StatusContext(ctx, venue, stock, id)
+}
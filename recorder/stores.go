@@ -0,0 +1,283 @@
+package recorder
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/donovanhide/stockfighter"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// record is the on-disk shape shared by the NDJSON and CSV stores: exactly
+// one of Quote, Execution or OrderState is set, matching Kind.
+type record struct {
+	Kind       string                   `json:"kind"`
+	Quote      *stockfighter.Quote      `json:"quote,omitempty"`
+	Execution  *stockfighter.Execution  `json:"execution,omitempty"`
+	OrderState *stockfighter.OrderState `json:"orderState,omitempty"`
+}
+
+func (r record) timestamp() time.Time {
+	switch {
+	case r.Quote != nil:
+		return r.Quote.QuoteTime
+	case r.Execution != nil:
+		return r.Execution.FilledAt
+	default:
+		return r.OrderState.Timestamp
+	}
+}
+
+func toRecord(rec record) Record {
+	return Record{
+		Kind:       rec.Kind,
+		Time:       rec.timestamp(),
+		Quote:      rec.Quote,
+		Execution:  rec.Execution,
+		OrderState: rec.OrderState,
+	}
+}
+
+// NDJSONStore writes one newline-delimited JSON record per event, buffering
+// a copy of each in memory so ReadAll can hand them straight to Replay
+// without requiring a second pass over the file.
+type NDJSONStore struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	records []Record
+}
+
+// NewNDJSONStore returns a Store that writes newline-delimited JSON to w.
+func NewNDJSONStore(w io.Writer) *NDJSONStore {
+	return &NDJSONStore{enc: json.NewEncoder(w)}
+}
+
+func (s *NDJSONStore) append(rec record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(rec); err != nil {
+		return err
+	}
+	s.records = append(s.records, toRecord(rec))
+	return nil
+}
+
+func (s *NDJSONStore) WriteQuote(q *stockfighter.Quote) error {
+	return s.append(record{Kind: "quote", Quote: q})
+}
+
+func (s *NDJSONStore) WriteExecution(e *stockfighter.Execution) error {
+	return s.append(record{Kind: "execution", Execution: e})
+}
+
+func (s *NDJSONStore) WriteOrderState(o *stockfighter.OrderState) error {
+	return s.append(record{Kind: "orderState", OrderState: o})
+}
+
+// ReadAll returns every Record written so far, in the order it was written.
+func (s *NDJSONStore) ReadAll() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Record, len(s.records))
+	copy(out, s.records)
+	return out, nil
+}
+
+func (s *NDJSONStore) Close() error { return nil }
+
+// ReadNDJSON reads back every record a previously-written NDJSONStore file
+// holds, for a process that wants to Replay a session it didn't itself
+// record.
+func ReadNDJSON(r io.Reader) ([]Record, error) {
+	var records []Record
+	dec := json.NewDecoder(r)
+	for {
+		var rec record
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, toRecord(rec))
+	}
+	return records, nil
+}
+
+// CSVStore writes one (kind, timestamp, JSON payload) row per event,
+// buffering a copy of each in memory so ReadAll can hand them straight to
+// Replay without requiring a second pass over the file.
+type CSVStore struct {
+	mu      sync.Mutex
+	w       *csv.Writer
+	records []Record
+}
+
+// NewCSVStore returns a Store that writes CSV rows to w.
+func NewCSVStore(w io.Writer) *CSVStore {
+	return &CSVStore{w: csv.NewWriter(w)}
+}
+
+func (s *CSVStore) append(rec record, ts time.Time, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.w.Write([]string{rec.Kind, ts.Format(time.RFC3339Nano), string(data)}); err != nil {
+		return err
+	}
+	s.w.Flush()
+	if err := s.w.Error(); err != nil {
+		return err
+	}
+	s.records = append(s.records, toRecord(rec))
+	return nil
+}
+
+func (s *CSVStore) WriteQuote(q *stockfighter.Quote) error {
+	return s.append(record{Kind: "quote", Quote: q}, q.QuoteTime, q)
+}
+
+func (s *CSVStore) WriteExecution(e *stockfighter.Execution) error {
+	return s.append(record{Kind: "execution", Execution: e}, e.FilledAt, e)
+}
+
+func (s *CSVStore) WriteOrderState(o *stockfighter.OrderState) error {
+	return s.append(record{Kind: "orderState", OrderState: o}, o.Timestamp, o)
+}
+
+// ReadAll returns every Record written so far, in the order it was written.
+func (s *CSVStore) ReadAll() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Record, len(s.records))
+	copy(out, s.records)
+	return out, nil
+}
+
+func (s *CSVStore) Close() error { return nil }
+
+// ReadCSV reads back every record a previously-written CSVStore file holds,
+// for a process that wants to Replay a session it didn't itself record.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 3 {
+			return nil, fmt.Errorf("recorder: short CSV row %v, want at least 3 fields", row)
+		}
+		kind, payload := row[0], []byte(row[2])
+		rec := record{Kind: kind}
+		switch kind {
+		case "quote":
+			rec.Quote = new(stockfighter.Quote)
+			err = json.Unmarshal(payload, rec.Quote)
+		case "execution":
+			rec.Execution = new(stockfighter.Execution)
+			err = json.Unmarshal(payload, rec.Execution)
+		case "orderState":
+			rec.OrderState = new(stockfighter.OrderState)
+			err = json.Unmarshal(payload, rec.OrderState)
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, toRecord(rec))
+	}
+	return records, nil
+}
+
+// SQLiteStore persists records into a single records(kind, ts, payload)
+// table in a SQLite database, ordered by an autoincrementing rowid.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path
+// and ensures its records table exists.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS records (
+		id      INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind    TEXT NOT NULL,
+		ts      TEXT NOT NULL,
+		payload TEXT NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) insert(kind string, ts time.Time, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO records (kind, ts, payload) VALUES (?, ?, ?)`, kind, ts.Format(time.RFC3339Nano), string(data))
+	return err
+}
+
+func (s *SQLiteStore) WriteQuote(q *stockfighter.Quote) error {
+	return s.insert("quote", q.QuoteTime, q)
+}
+
+func (s *SQLiteStore) WriteExecution(e *stockfighter.Execution) error {
+	return s.insert("execution", e.FilledAt, e)
+}
+
+func (s *SQLiteStore) WriteOrderState(o *stockfighter.OrderState) error {
+	return s.insert("orderState", o.Timestamp, o)
+}
+
+// ReadAll queries every record back from the database, in insertion order.
+// Unlike NDJSONStore and CSVStore it reads from the database rather than an
+// in-memory buffer, so it also sees records inserted by another process
+// sharing the same file.
+func (s *SQLiteStore) ReadAll() ([]Record, error) {
+	rows, err := s.db.Query(`SELECT kind, payload FROM records ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var kind, payload string
+		if err := rows.Scan(&kind, &payload); err != nil {
+			return nil, err
+		}
+		rec := record{Kind: kind}
+		switch kind {
+		case "quote":
+			rec.Quote = new(stockfighter.Quote)
+			err = json.Unmarshal([]byte(payload), rec.Quote)
+		case "execution":
+			rec.Execution = new(stockfighter.Execution)
+			err = json.Unmarshal([]byte(payload), rec.Execution)
+		case "orderState":
+			rec.OrderState = new(stockfighter.OrderState)
+			err = json.Unmarshal([]byte(payload), rec.OrderState)
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, toRecord(rec))
+	}
+	return records, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error { return s.db.Close() }
@@ -0,0 +1,161 @@
+package stockfighter
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Option configures a Stockfighter instance constructed with
+// NewStockfighterWithOptions.
+type Option func(*Stockfighter)
+
+// WithDebug enables or disables logging of HTTP requests and responses.
+func WithDebug(debug bool) Option {
+	return func(sf *Stockfighter) { sf.debug = debug }
+}
+
+// WithHTTPClient overrides the *http.Client used for every API call. If the
+// client has no Transport set, the default retrying Transport is installed
+// so requests still get 429/5xx retry behaviour. A rate limiter already
+// applied by WithRateLimit is carried over onto client's transport chain
+// regardless of option order, so WithRateLimit and WithHTTPClient can be
+// passed to NewStockfighterWithOptions in either order.
+func WithHTTPClient(client *http.Client) Option {
+	return func(sf *Stockfighter) {
+		limiter := findRateLimiter(sf.client.Transport)
+		if client.Transport == nil {
+			client.Transport = &retryTransport{next: http.DefaultTransport}
+		}
+		if limiter != nil {
+			client.Transport = &rateLimitedTransport{limiter: limiter, next: client.Transport}
+		}
+		sf.client = client
+	}
+}
+
+// WithRateLimit caps outgoing API requests to r per second with bursts up to
+// burst, giving market-making and arbitrage loops backpressure instead of
+// hammering the API and losing orders to 429s.
+func WithRateLimit(r rate.Limit, burst int) Option {
+	return func(sf *Stockfighter) {
+		sf.client.Transport = &rateLimitedTransport{
+			limiter: rate.NewLimiter(r, burst),
+			next:    sf.client.Transport,
+		}
+	}
+}
+
+// rateLimitedTransport gates outgoing requests through a token bucket before
+// handing them to the next RoundTripper in the chain.
+type rateLimitedTransport struct {
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+// findRateLimiter walks a RoundTripper chain looking for a rateLimitedTransport
+// already installed by WithRateLimit, so WithHTTPClient can carry its limiter
+// over onto a replacement client rather than silently dropping it.
+func findRateLimiter(rt http.RoundTripper) *rate.Limiter {
+	for rt != nil {
+		switch t := rt.(type) {
+		case *rateLimitedTransport:
+			return t.limiter
+		case *retryTransport:
+			rt = t.next
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
+
+// defaultMaxRetries bounds how many times retryTransport will retry a
+// request that keeps failing with 429 or 5xx.
+const defaultMaxRetries = 5
+
+// retryTransport retries requests that fail with a 429 or 5xx response,
+// using exponential backoff with jitter and honoring a Retry-After header
+// when the server sends one.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxRetries := t.maxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil || attempt == maxRetries || !shouldRetry(resp) {
+			return resp, err
+		}
+		wait := retryAfter(resp)
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		resp.Body.Close()
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func shouldRetry(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter reads the server's requested backoff from a Retry-After header
+// given in seconds, returning 0 if absent or unparseable.
+func retryAfter(resp *http.Response) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return 0
+}
+
+// backoff returns an exponentially growing delay with full jitter, doubling
+// a 100ms base on every attempt.
+func backoff(attempt int) time.Duration {
+	base := 100 * time.Millisecond << uint(attempt)
+	return time.Duration(rand.Int63n(int64(base) + 1))
+}
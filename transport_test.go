@@ -0,0 +1,123 @@
+package stockfighter
+
+import (
+	"net/http"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper so tests can fake
+// responses without opening a real connection.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newResponse(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       http.NoBody,
+	}
+}
+
+func TestRetryTransportRetriesOnTooManyRequests(t *testing.T) {
+	var attempts int
+	rt := &retryTransport{
+		maxRetries: 2,
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts < 3 {
+				return newResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}}), nil
+			}
+			return newResponse(http.StatusOK, nil), nil
+		}),
+	}
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestRetryTransportGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int
+	rt := &retryTransport{
+		maxRetries: 2,
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return newResponse(http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"0"}}), nil
+		}),
+	}
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("StatusCode = %d, want 503", resp.StatusCode)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want maxRetries+1 = 3", attempts)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	cases := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadRequest, false},
+	}
+	for _, c := range cases {
+		if got := shouldRetry(newResponse(c.status, nil)); got != c.want {
+			t.Errorf("shouldRetry(%d) = %v, want %v", c.status, got, c.want)
+		}
+	}
+}
+
+func TestRetryAfterParsesSeconds(t *testing.T) {
+	resp := newResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"2"}})
+	if got := retryAfter(resp); got != 2_000_000_000 {
+		t.Errorf("retryAfter() = %v, want 2s", got)
+	}
+	if got := retryAfter(newResponse(http.StatusOK, nil)); got != 0 {
+		t.Errorf("retryAfter() with no header = %v, want 0", got)
+	}
+}
+
+func TestBackoffGrowsWithAttempt(t *testing.T) {
+	if got := backoff(0); got > 100_000_000 {
+		t.Errorf("backoff(0) = %v, want <= 100ms", got)
+	}
+	if got := backoff(3); got > 800_000_000 {
+		t.Errorf("backoff(3) = %v, want <= 800ms", got)
+	}
+}
+
+// TestWithHTTPClientPreservesRateLimit verifies that applying WithRateLimit
+// before WithHTTPClient does not silently drop the limiter: the option order
+// a caller happens to write should not change the resulting transport chain.
+func TestWithHTTPClientPreservesRateLimit(t *testing.T) {
+	sf := NewStockfighterWithOptions("key",
+		WithRateLimit(rate.Limit(10), 1),
+		WithHTTPClient(&http.Client{}),
+	)
+	if findRateLimiter(sf.client.Transport) == nil {
+		t.Fatal("rate limiter was dropped when WithHTTPClient was applied after WithRateLimit")
+	}
+}
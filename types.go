@@ -44,6 +44,25 @@ type Symbol struct {
 	Name   string
 }
 
+// SymbolInfo describes the trading constraints for a symbol on a venue: the
+// smallest price increment and quantity increment the venue will accept, in
+// the same units as Order.Price and Order.Quantity.
+type SymbolInfo struct {
+	Venue         string
+	Symbol        string
+	PriceTick     uint64
+	LotSize       uint64
+	QuoteCurrency string
+}
+
+// ErrInvalidTickSize is returned by Place when Order.Price is not a
+// multiple of the target symbol's PriceTick.
+var ErrInvalidTickSize = fmt.Errorf("stockfighter: price is not a multiple of the symbol's tick size")
+
+// ErrInvalidLotSize is returned by Place when Order.Quantity is not a
+// multiple of the target symbol's LotSize.
+var ErrInvalidLotSize = fmt.Errorf("stockfighter: quantity is not a multiple of the symbol's lot size")
+
 type Order struct {
 	Account   string    `json:"account"`
 	Venue     string    `json:"venue"`